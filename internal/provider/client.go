@@ -0,0 +1,16 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package googleworkspace
+
+import "net/http"
+
+// newProviderRoundTripper builds the RoundTripper chain used for every
+// outbound Workspace admin API call: idempotencyTransport tags each
+// original mutating request with a stable token before handing it to
+// retryTransport, which owns the actual retry loop and backoff.
+//
+//	request -> idempotencyTransport -> retryTransport -> base
+func newProviderRoundTripper(base http.RoundTripper, retryConfig *RetryConfig) http.RoundTripper {
+	return newIdempotencyTransport(NewTransportWithDefaultRetries(base, retryConfig))
+}
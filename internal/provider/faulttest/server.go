@@ -0,0 +1,145 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package faulttest provides an httptest.Server that can be scripted to
+// inject the faults retryTransport is supposed to recover from (or
+// short-circuit on). Tests drive it by sending an X-Test-Instructions
+// header listing one instruction per attempt, comma separated:
+//
+//	"503,503,200"              -> two 503s then a success
+//	"reset,200"                -> a connection reset then a success
+//	"slow:30s"                 -> sleep before responding (for deadline tests)
+//	"429:retry-after=5,200"    -> a 429 with Retry-After: 5 then a success
+//	"truncate"                 -> a response whose body is cut off mid-write
+//
+// This mirrors the retry-conformance approach used in google-cloud-go's
+// storage tests, where a test proxy injects failures per operation.
+package faulttest
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// InstructionsHeader is the request header clients use to script the
+// sequence of faults the Server should inject, one instruction per attempt.
+const InstructionsHeader = "X-Test-Instructions"
+
+// Server is an httptest.Server that replays a scripted fault sequence.
+// Each request's attempt count is tracked independently per the value of
+// the X-Test-Instructions header, so concurrent tests don't interfere.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+// NewServer starts a fault-injection server and returns it. Callers must
+// call Close when done, same as httptest.NewServer.
+func NewServer() *Server {
+	s := &Server{attempts: map[string]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	script := r.Header.Get(InstructionsHeader)
+	instructions := strings.Split(script, ",")
+
+	s.mu.Lock()
+	attempt := s.attempts[script]
+	s.attempts[script] = attempt + 1
+	s.mu.Unlock()
+
+	if attempt >= len(instructions) {
+		// Script exhausted; keep returning the last instruction's outcome.
+		attempt = len(instructions) - 1
+	}
+
+	applyInstruction(w, instructions[attempt])
+}
+
+// Attempts returns how many requests have been received so far for script.
+func (s *Server) Attempts(script string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts[script]
+}
+
+func applyInstruction(w http.ResponseWriter, instruction string) {
+	parts := strings.SplitN(instruction, ":", 2)
+	op := parts[0]
+	arg := ""
+	if len(parts) == 2 {
+		arg = parts[1]
+	}
+
+	switch {
+	case op == "reset" || op == "reset-connection":
+		hijackAndReset(w)
+	case op == "slow" || op == "slow-body":
+		sleepFor(arg)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "ok")
+	case op == "truncate" || op == "truncated-body":
+		w.Header().Set("Content-Length", "100")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "short")
+		hijackAndReset(w)
+	default:
+		code, retryAfter := parseStatus(op, arg)
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(code)
+		fmt.Fprintf(w, `{"error":{"code":%d,"message":"fault injected"}}`, code)
+	}
+}
+
+// parseStatus parses an instruction like "429" or "429:retry-after=5" into
+// a status code and an optional Retry-After header value.
+func parseStatus(op, arg string) (int, string) {
+	code, err := strconv.Atoi(op)
+	if err != nil {
+		code = http.StatusOK
+	}
+	retryAfter := ""
+	for _, kv := range strings.Split(arg, ";") {
+		if strings.HasPrefix(kv, "retry-after=") {
+			retryAfter = strings.TrimPrefix(kv, "retry-after=")
+		}
+	}
+	return code, retryAfter
+}
+
+func sleepFor(arg string) {
+	d, err := time.ParseDuration(arg)
+	if err != nil {
+		return
+	}
+	time.Sleep(d)
+}
+
+// hijackAndReset abruptly closes the underlying connection to simulate a
+// connection reset mid-response.
+func hijackAndReset(w http.ResponseWriter) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetLinger(0)
+	}
+	conn.Close()
+}
@@ -0,0 +1,87 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package googleworkspace
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// idempotencyTokenHeader is the header retried mutating requests carry so
+// the Workspace admin endpoints can dedup retried creates/updates/deletes
+// against the original attempt, the same pattern google-cloud-go's storage
+// client uses for its idempotency header.
+const idempotencyTokenHeader = "X-Terraform-Request-Id"
+
+var idempotentMethods = map[string]bool{
+	http.MethodPost:   true,
+	http.MethodPatch:  true,
+	http.MethodPut:    true,
+	http.MethodDelete: true,
+}
+
+// workspaceAdminPathPrefixes lists the Admin SDK / Workspace API path roots
+// whose mutating calls create server-side resources, and so are worth
+// deduping on retry. Everything else (OAuth2 token refresh, metadata
+// servers, etc.) is left untagged.
+var workspaceAdminPathPrefixes = []string{
+	"/admin/directory/",
+	"/admin/reports/",
+	"/apps/licensing/",
+	"/groups/v1/groups",
+}
+
+// isWorkspaceAdminRequest reports whether req targets a Workspace admin
+// endpoint, as opposed to infrastructure calls like OAuth2 token refresh
+// that happen to share the googleapis.com host but aren't safe or useful to
+// tag with a Workspace idempotency token.
+func isWorkspaceAdminRequest(req *http.Request) bool {
+	if req.URL == nil || !strings.HasSuffix(req.URL.Hostname(), "googleapis.com") {
+		return false
+	}
+	for _, prefix := range workspaceAdminPathPrefixes {
+		if strings.HasPrefix(req.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// idempotencyTransport generates a UUIDv4 idempotency token once per
+// original request and attaches it as a stable header, so that retries of
+// the same logical call (driven by the wrapped retryTransport) present the
+// same token on every attempt. It must wrap retryTransport, not the other
+// way around, since the token needs to be set once before the retry loop
+// starts, not once per attempt.
+type idempotencyTransport struct {
+	internal http.RoundTripper
+}
+
+// newIdempotencyTransport wraps t so that POST/PATCH/PUT/DELETE requests
+// against Workspace admin endpoints carry a stable idempotency token across
+// all of t's retry attempts.
+func newIdempotencyTransport(t http.RoundTripper) *idempotencyTransport {
+	return &idempotencyTransport{internal: t}
+}
+
+// RoundTrip implements the RoundTripper interface method.
+func (t *idempotencyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !idempotentMethods[req.Method] || !isWorkspaceAdminRequest(req) {
+		return t.internal.RoundTrip(req)
+	}
+	if req.Header.Get(idempotencyTokenHeader) == "" {
+		// RoundTrip must not mutate req (http.RoundTripper contract), so tag
+		// a clone. The shallow header map copy in copyHttpRequest still
+		// shares this clone's Header, so the token is preserved across all
+		// of retryTransport's retry attempts.
+		req = req.Clone(req.Context())
+		token := uuid.New().String()
+		req.Header.Set(idempotencyTokenHeader, token)
+		log.Printf("[DEBUG] Idempotency Transport: tagged %s %s with %s=%s", req.Method, req.URL.Path, idempotencyTokenHeader, token)
+	}
+	return t.internal.RoundTrip(req)
+}
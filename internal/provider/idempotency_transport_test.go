@@ -0,0 +1,151 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package googleworkspace
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsWorkspaceAdminRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want bool
+	}{
+		{"directory API", "https://www.googleapis.com/admin/directory/v1/users", true},
+		{"reports API", "https://www.googleapis.com/admin/reports/v1/activity/users/all", true},
+		{"licensing API", "https://www.googleapis.com/apps/licensing/v1/product/x", true},
+		{"groups settings API", "https://www.googleapis.com/groups/v1/groups/my-group", true},
+		{"oauth2 token refresh", "https://oauth2.googleapis.com/token", false},
+		{"non-admin googleapis path", "https://www.googleapis.com/storage/v1/b", false},
+		{"non-google host", "https://example.com/admin/directory/v1/users", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, tt.url, nil)
+			if err != nil {
+				t.Fatalf("failed to build request: %v", err)
+			}
+			if got := isWorkspaceAdminRequest(req); got != tt.want {
+				t.Fatalf("isWorkspaceAdminRequest(%s) = %v, want %v", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+// recordingRoundTripper records every request it sees and returns a canned
+// 200 response.
+type recordingRoundTripper struct {
+	requests []*http.Request
+}
+
+func (r *recordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.requests = append(r.requests, req)
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}}, nil
+}
+
+func TestIdempotencyTransport_TagsAdminMutatingRequests(t *testing.T) {
+	recorder := &recordingRoundTripper{}
+	transport := newIdempotencyTransport(recorder)
+
+	req, err := http.NewRequest(http.MethodPost, "https://www.googleapis.com/admin/directory/v1/users", nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+
+	if len(recorder.requests) != 1 {
+		t.Fatalf("expected 1 request to reach the inner transport, got %d", len(recorder.requests))
+	}
+	if token := recorder.requests[0].Header.Get(idempotencyTokenHeader); token == "" {
+		t.Fatalf("expected %s to be set on the request reaching the inner transport", idempotencyTokenHeader)
+	}
+	if req.Header.Get(idempotencyTokenHeader) != "" {
+		t.Fatalf("RoundTrip must not mutate the caller's request, but the original request now carries %s", idempotencyTokenHeader)
+	}
+}
+
+func TestIdempotencyTransport_DoesNotTagNonMutatingRequests(t *testing.T) {
+	recorder := &recordingRoundTripper{}
+	transport := newIdempotencyTransport(recorder)
+
+	req, err := http.NewRequest(http.MethodGet, "https://www.googleapis.com/admin/directory/v1/users", nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+
+	if token := recorder.requests[0].Header.Get(idempotencyTokenHeader); token != "" {
+		t.Fatalf("expected GET requests to be left untagged, got %s=%s", idempotencyTokenHeader, token)
+	}
+}
+
+// copyingRoundTripper simulates retryTransport's attempt loop: it calls
+// copyHttpRequest twice, recording the idempotency token seen on each
+// resulting copy, before returning a canned 200 response.
+type copyingRoundTripper struct {
+	tokensSeen []string
+}
+
+func (c *copyingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	for i := 0; i < 2; i++ {
+		attempt, err := copyHttpRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		c.tokensSeen = append(c.tokensSeen, attempt.Header.Get(idempotencyTokenHeader))
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}}, nil
+}
+
+func TestIdempotencyTransport_TokenPreservedAcrossRetryAttempts(t *testing.T) {
+	inner := &copyingRoundTripper{}
+	transport := newIdempotencyTransport(inner)
+
+	req, err := http.NewRequest(http.MethodPost, "https://www.googleapis.com/admin/directory/v1/users", nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+
+	if len(inner.tokensSeen) != 2 {
+		t.Fatalf("expected 2 simulated attempts, got %d", len(inner.tokensSeen))
+	}
+	if inner.tokensSeen[0] == "" {
+		t.Fatalf("expected a non-empty idempotency token on the first attempt")
+	}
+	if inner.tokensSeen[0] != inner.tokensSeen[1] {
+		t.Fatalf("expected the same idempotency token across retry attempts, got %q and %q", inner.tokensSeen[0], inner.tokensSeen[1])
+	}
+}
+
+func TestIsWorkspaceAdminRequest_RealListenerHostDoesNotMatch(t *testing.T) {
+	// Sanity check that isWorkspaceAdminRequest's host match is exercised
+	// against a real *http.Request built from a live listener, not just
+	// hand-rolled URLs.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/admin/directory/v1/users", nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	if isWorkspaceAdminRequest(req) {
+		t.Fatalf("expected the local test server host not to match googleapis.com")
+	}
+}
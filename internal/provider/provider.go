@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package googleworkspace
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// Provider returns the googleworkspace Terraform provider.
+func Provider() *schema.Provider {
+	return &schema.Provider{
+		Schema: map[string]*schema.Schema{
+			"retry": retrySchema(),
+		},
+		ConfigureContextFunc: providerConfigure,
+	}
+}
+
+// providerMeta is the value ConfigureContextFunc hands to every resource's
+// CRUD funcs via meta.(*providerMeta).
+type providerMeta struct {
+	client *http.Client
+}
+
+// providerConfigure builds the HTTP client every resource uses to talk to
+// the Workspace Admin SDK, applying the operator's retry block (if any) to
+// the retryTransport in the client's RoundTripper chain.
+func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+	retryConfig, err := expandRetryConfig(d)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	client := &http.Client{
+		Transport: newProviderRoundTripper(http.DefaultTransport, retryConfig),
+	}
+
+	return &providerMeta{client: client}, nil
+}
@@ -0,0 +1,142 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package googleworkspace
+
+import (
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// retrySchema returns the provider-level `retry` block definition. It lets
+// operators tune the behavior of retryTransport instead of relying on the
+// hardcoded defaults in DefaultRetryConfig.
+func retrySchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"max_attempts": {
+					Type:        schema.TypeInt,
+					Optional:    true,
+					Default:     defaultMaxAttempts,
+					Description: "Maximum number of attempts per request, including the first. 0 means unlimited, bounded only by total_timeout.",
+				},
+				"initial_backoff": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      defaultInitialBackoff.String(),
+					Description:  "Wait duration before the first retry, e.g. \"500ms\".",
+					ValidateFunc: validateDuration(),
+				},
+				"max_backoff": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      defaultMaxBackoff.String(),
+					Description:  "Maximum wait duration between any two attempts, e.g. \"60s\".",
+					ValidateFunc: validateDuration(),
+				},
+				"total_timeout": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					Default:      (defaultRetryTransportTimeoutSec * time.Second).String(),
+					Description:  "Upper bound on the entire retry loop when the request doesn't already carry a deadline, e.g. \"90s\". An attempt already in flight when it expires is not cut short.",
+					ValidateFunc: validateDuration(),
+				},
+				"strategy": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Default:     string(defaultRetryStrategy),
+					Description: "Backoff strategy to use between attempts. One of fibonacci, exponential, exponential_jitter.",
+					ValidateFunc: validation.StringInSlice([]string{
+						string(RetryStrategyFibonacci),
+						string(RetryStrategyExponential),
+						string(RetryStrategyExponentialJitter),
+					}, false),
+				},
+				"retry_on_status": {
+					Type:        schema.TypeList,
+					Optional:    true,
+					Description: "Additional HTTP status codes to retry on, on top of the provider's built-in predicates.",
+					Elem:        &schema.Schema{Type: schema.TypeInt},
+				},
+				"retry_on_reasons": {
+					Type:        schema.TypeList,
+					Optional:    true,
+					Description: "Additional googleapi error reason strings (e.g. \"rateLimitExceeded\") to retry on.",
+					Elem:        &schema.Schema{Type: schema.TypeString},
+				},
+			},
+		},
+	}
+}
+
+// validateDuration validates that a string parses with time.ParseDuration.
+func validateDuration() schema.SchemaValidateFunc {
+	return func(v interface{}, k string) (warnings []string, errs []error) {
+		if _, err := time.ParseDuration(v.(string)); err != nil {
+			errs = append(errs, err)
+		}
+		return
+	}
+}
+
+// expandRetryConfig reads the provider-level `retry` block (if set) into a
+// RetryConfig, falling back to DefaultRetryConfig for any field left unset.
+func expandRetryConfig(d *schema.ResourceData) (*RetryConfig, error) {
+	cfg := DefaultRetryConfig()
+
+	raw, ok := d.GetOk("retry")
+	if !ok {
+		return cfg, nil
+	}
+	blocks := raw.([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return cfg, nil
+	}
+	block := blocks[0].(map[string]interface{})
+
+	if v, ok := block["max_attempts"].(int); ok {
+		cfg.MaxAttempts = v
+	}
+	if v, ok := block["initial_backoff"].(string); ok && v != "" {
+		dur, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, err
+		}
+		cfg.InitialBackoff = dur
+	}
+	if v, ok := block["max_backoff"].(string); ok && v != "" {
+		dur, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, err
+		}
+		cfg.MaxBackoff = dur
+	}
+	if v, ok := block["total_timeout"].(string); ok && v != "" {
+		dur, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, err
+		}
+		cfg.TotalTimeout = dur
+	}
+	if v, ok := block["strategy"].(string); ok && v != "" {
+		cfg.Strategy = RetryStrategy(v)
+	}
+	if v, ok := block["retry_on_status"].([]interface{}); ok {
+		for _, s := range v {
+			cfg.RetryOnStatus = append(cfg.RetryOnStatus, s.(int))
+		}
+	}
+	if v, ok := block["retry_on_reasons"].([]interface{}); ok {
+		for _, r := range v {
+			cfg.RetryOnReasons = append(cfg.RetryOnReasons, r.(string))
+		}
+	}
+
+	return cfg, nil
+}
@@ -12,23 +12,157 @@ import (
 	"google.golang.org/api/googleapi"
 	"io/ioutil"
 	"log"
+	"math/rand"
 	"net/http"
 	"net/http/httputil"
+	"strconv"
 	"time"
 )
 
 const defaultRetryTransportTimeoutSec = 90
 
+// RetryStrategy selects the backoff generator used between retry attempts.
+type RetryStrategy string
+
+const (
+	RetryStrategyFibonacci         RetryStrategy = "fibonacci"
+	RetryStrategyExponential       RetryStrategy = "exponential"
+	RetryStrategyExponentialJitter RetryStrategy = "exponential_jitter"
+	defaultRetryStrategy                         = RetryStrategyFibonacci
+	defaultMaxAttempts                           = 0 // 0 means unlimited, bounded only by TotalTimeout
+	defaultInitialBackoff                        = time.Millisecond * 500
+	defaultMaxBackoff                            = time.Second * 60
+)
+
+// RetryConfig controls how a retryTransport retries requests: how many
+// attempts it will make, how it waits between attempts, and how long the
+// overall retry loop is allowed to run. A zero-value RetryConfig is not
+// valid; use DefaultRetryConfig or NewTransportWithDefaultRetries.
+type RetryConfig struct {
+	// MaxAttempts caps the number of attempts made, including the first.
+	// 0 means unlimited (bounded only by TotalTimeout).
+	MaxAttempts int
+
+	// InitialBackoff is the wait before the first retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait between any two attempts.
+	MaxBackoff time.Duration
+
+	// TotalTimeout bounds the entire retry loop when the incoming request's
+	// context doesn't already carry a deadline. Once it expires no further
+	// wait or attempt is started; an attempt already in flight runs under the
+	// request's own context and is not cut short by it.
+	TotalTimeout time.Duration
+
+	// Strategy selects the backoff generator used between attempts.
+	Strategy RetryStrategy
+
+	// RetryOnStatus lists additional HTTP status codes that should be
+	// treated as retryable, on top of the provider's default predicates.
+	RetryOnStatus []int
+
+	// RetryOnReasons lists additional googleapi error "reason" values
+	// (e.g. "rateLimitExceeded") that should be treated as retryable.
+	RetryOnReasons []string
+}
+
+// DefaultRetryConfig returns the RetryConfig matching the provider's
+// historical hardcoded behavior: unlimited attempts, Fibonacci backoff, and
+// a 90 second total timeout.
+func DefaultRetryConfig() *RetryConfig {
+	return &RetryConfig{
+		MaxAttempts:    defaultMaxAttempts,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     defaultMaxBackoff,
+		TotalTimeout:   defaultRetryTransportTimeoutSec * time.Second,
+		Strategy:       defaultRetryStrategy,
+	}
+}
+
+// backoffGenerator returns a func that produces the next backoff duration
+// each time it's called, according to cfg.Strategy.
+func (cfg *RetryConfig) backoffGenerator() func() time.Duration {
+	backoff := cfg.InitialBackoff
+	nextBackoff := cfg.InitialBackoff
+
+	return func() time.Duration {
+		current := backoff
+		if cfg.MaxBackoff > 0 && current > cfg.MaxBackoff {
+			current = cfg.MaxBackoff
+		}
+		switch cfg.Strategy {
+		case RetryStrategyExponential, RetryStrategyExponentialJitter:
+			backoff = backoff * 2
+		default:
+			// Fibonacci backoff - 0.5, 1, 1.5, 2.5, 4, 6.5, 10.5, ...
+			lastBackoff := backoff
+			backoff = backoff + nextBackoff
+			nextBackoff = lastBackoff
+		}
+		if cfg.MaxBackoff > 0 && backoff > cfg.MaxBackoff {
+			backoff = cfg.MaxBackoff
+		}
+		if cfg.Strategy == RetryStrategyExponentialJitter {
+			current = time.Duration(float64(current) * (0.5 + rand.Float64()/2))
+		}
+		return current
+	}
+}
+
+type retryTransportContextKey struct{}
+type noRetryContextKey struct{}
+
+// NoRetry returns a context marking the request it's attached to as
+// non-retryable at the retryTransport layer. Use this when the caller
+// already has its own retry loop around the request (e.g.
+// resource.RetryContext around a group membership sync) so the two loops
+// don't compound each other's wait times and blow past Terraform's
+// operation timeout.
+func NoRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryContextKey{}, true)
+}
+
+// isNoRetry reports whether ctx was marked non-retryable via NoRetry.
+func isNoRetry(ctx context.Context) bool {
+	noRetry, _ := ctx.Value(noRetryContextKey{}).(bool)
+	return noRetry
+}
+
+// ContextWithRetryConfig returns a context carrying a RetryConfig that
+// overrides the retryTransport's provider-level default for the lifetime of
+// a single logical call. Resource CRUD funcs that need a different timeout
+// than the rest of the provider (e.g. google_workspace_user, which can wait
+// on slow eventual-consistency group memberships) should pass the returned
+// context down to the API call they make.
+func ContextWithRetryConfig(ctx context.Context, cfg *RetryConfig) context.Context {
+	return context.WithValue(ctx, retryTransportContextKey{}, cfg)
+}
+
+// retryConfigFromContext returns the per-call RetryConfig override attached
+// via ContextWithRetryConfig, if any.
+func retryConfigFromContext(ctx context.Context) (*RetryConfig, bool) {
+	cfg, ok := ctx.Value(retryTransportContextKey{}).(*RetryConfig)
+	return cfg, ok
+}
+
 type retryTransport struct {
 	retryPredicates []RetryErrorPredicateFunc
 	internal        http.RoundTripper
+	config          *RetryConfig
 }
 
-// NewTransportWithDefaultRetries constructs a default retryTransport that will retry common temporary errors
-func NewTransportWithDefaultRetries(t http.RoundTripper) *retryTransport {
+// NewTransportWithDefaultRetries constructs a retryTransport that will retry
+// common temporary errors according to cfg. A nil cfg falls back to
+// DefaultRetryConfig.
+func NewTransportWithDefaultRetries(t http.RoundTripper, cfg *RetryConfig) *retryTransport {
+	if cfg == nil {
+		cfg = DefaultRetryConfig()
+	}
 	return &retryTransport{
 		retryPredicates: defaultErrorRetryPredicates,
 		internal:        t,
+		config:          cfg,
 	}
 }
 
@@ -36,11 +170,21 @@ func NewTransportWithDefaultRetries(t http.RoundTripper) *retryTransport {
 // It retries the given HTTP request based on the retry predicates
 // registered under the retryTransport.
 func (t *retryTransport) RoundTrip(req *http.Request) (resp *http.Response, respErr error) {
+	if isNoRetry(req.Context()) {
+		log.Printf("[DEBUG] Retry Transport: context marked non-retryable, running request once")
+		return t.internal.RoundTrip(req)
+	}
+
+	config := t.config
+	if override, ok := retryConfigFromContext(req.Context()); ok {
+		config = override
+	}
+
 	// Set timeout to default value.
 	ctx := req.Context()
 	var ccancel context.CancelFunc
 	if _, ok := ctx.Deadline(); !ok {
-		ctx, ccancel = context.WithTimeout(ctx, defaultRetryTransportTimeoutSec*time.Second)
+		ctx, ccancel = context.WithTimeout(ctx, config.TotalTimeout)
 		defer func() {
 			if ctx.Err() == nil {
 				// Cleanup child context created for retry loop if ctx not done.
@@ -50,8 +194,8 @@ func (t *retryTransport) RoundTrip(req *http.Request) (resp *http.Response, resp
 	}
 
 	attempts := 0
-	backoff := time.Millisecond * 500
-	nextBackoff := time.Millisecond * 500
+	genBackoff := config.backoffGenerator()
+	backoff := genBackoff()
 
 	// VCR depends on the original request body being consumed, so
 	// consume here. Since this won't affect the request itself,
@@ -80,7 +224,7 @@ Retry:
 		resp, respErr = t.internal.RoundTrip(newRequest)
 		attempts++
 
-		retryErr := t.checkForRetryableError(resp, respErr)
+		retryErr := t.checkForRetryableError(config, resp, respErr)
 		if retryErr == nil {
 			log.Printf("[DEBUG] Retry Transport: Stopping retries, last request was successful")
 			break Retry
@@ -89,6 +233,23 @@ Retry:
 			log.Printf("[DEBUG] Retry Transport: Stopping retries, last request failed with non-retryable error: %s", retryErr.Err)
 			break Retry
 		}
+		if config.MaxAttempts > 0 && attempts >= config.MaxAttempts {
+			log.Printf("[DEBUG] Retry Transport: Stopping retries, reached max attempts (%d)", config.MaxAttempts)
+			break Retry
+		}
+
+		if retryAfter, ok := retryAfterFromResponse(resp); ok {
+			log.Printf("[DEBUG] Retry Transport: Server advised Retry-After: %s", retryAfter)
+			backoff = retryAfter
+			if config.MaxBackoff > 0 && backoff > config.MaxBackoff {
+				backoff = config.MaxBackoff
+			}
+			if deadline, ok := ctx.Deadline(); ok {
+				if remaining := time.Until(deadline); backoff > remaining {
+					backoff = remaining
+				}
+			}
+		}
 
 		log.Printf("[DEBUG] Retry Transport: Waiting %s before trying request again", backoff)
 		select {
@@ -97,11 +258,7 @@ Retry:
 			break Retry
 		case <-time.After(backoff):
 			log.Printf("[DEBUG] Retry Transport: Finished waiting %s before next retry", backoff)
-
-			// Fibonnaci backoff - 0.5, 1, 1.5, 2.5, 4, 6.5, 10.5, ...
-			lastBackoff := backoff
-			backoff = backoff + nextBackoff
-			nextBackoff = lastBackoff
+			backoff = genBackoff()
 			continue
 		}
 	}
@@ -112,7 +269,7 @@ Retry:
 // checkForRetryableError uses the googleapi.CheckResponse util to check for
 // errors in the response, and determines whether there is a retryable error.
 // in response/response error.
-func (t *retryTransport) checkForRetryableError(resp *http.Response, respErr error) *resource.RetryError {
+func (t *retryTransport) checkForRetryableError(config *RetryConfig, resp *http.Response, respErr error) *resource.RetryError {
 	var errToCheck error
 
 	if respErr != nil {
@@ -137,12 +294,71 @@ func (t *retryTransport) checkForRetryableError(resp *http.Response, respErr err
 	if errToCheck == nil {
 		return nil
 	}
-	if isRetryableError(errToCheck, t.retryPredicates...) {
+	predicates := t.retryPredicates
+	if config != nil && (len(config.RetryOnStatus) > 0 || len(config.RetryOnReasons) > 0) {
+		predicates = append(append([]RetryErrorPredicateFunc{}, predicates...), configRetryPredicate(config))
+	}
+	if isRetryableError(errToCheck, predicates...) {
 		return resource.RetryableError(errToCheck)
 	}
 	return resource.NonRetryableError(errToCheck)
 }
 
+// configRetryPredicate builds a RetryErrorPredicateFunc honoring the
+// additional status codes and googleapi error reasons configured via the
+// provider's retry block, on top of the provider's built-in predicates.
+func configRetryPredicate(config *RetryConfig) RetryErrorPredicateFunc {
+	return func(err error) (bool, string) {
+		gerr, ok := err.(*googleapi.Error)
+		if !ok {
+			return false, ""
+		}
+		for _, status := range config.RetryOnStatus {
+			if gerr.Code == status {
+				return true, fmt.Sprintf("retryable status %d configured via provider retry block", status)
+			}
+		}
+		for _, errDetail := range gerr.Errors {
+			for _, reason := range config.RetryOnReasons {
+				if errDetail.Reason == reason {
+					return true, fmt.Sprintf("retryable reason %q configured via provider retry block", reason)
+				}
+			}
+		}
+		return false, ""
+	}
+}
+
+// retryAfterFromResponse parses a Retry-After header off of 429 and 503
+// responses per RFC 7231 section 7.1.3, supporting both the delta-seconds
+// and HTTP-date forms. It reports false if resp is nil, the status isn't
+// one the Admin SDK/Directory API use Retry-After with, or the header is
+// absent or unparsable.
+func retryAfterFromResponse(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	header := resp.Header.Get("Retry-After")
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
 // copyHttpRequest provides an copy of the given HTTP request for one RoundTrip.
 // If the request has a non-empty body (io.ReadCloser), the body is deep copied
 // so it can be consumed.
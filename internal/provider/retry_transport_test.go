@@ -0,0 +1,209 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package googleworkspace
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/public-cloud-wl/terraform-provider-googleworkspace/internal/provider/faulttest"
+)
+
+// newBodyRequest builds a POST request whose GetBody is instrumented so
+// tests can assert it's called exactly once per attempt: copyHttpRequest
+// calls GetBody on every attempt, including the first, to produce the copy
+// handed to the wrapped RoundTripper.
+func newBodyRequest(t *testing.T, url, script string, getBodyCalls *int) *http.Request {
+	t.Helper()
+	body := []byte(`{"hello":"world"}`)
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	req.GetBody = func() (io.ReadCloser, error) {
+		*getBodyCalls++
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.Header.Set(faulttest.InstructionsHeader, script)
+	return req
+}
+
+func fastTestRetryConfig() *RetryConfig {
+	cfg := DefaultRetryConfig()
+	cfg.InitialBackoff = time.Millisecond
+	cfg.MaxBackoff = 5 * time.Millisecond
+	cfg.TotalTimeout = time.Second
+	return cfg
+}
+
+func TestRetryTransport_RetriesUntilSuccess(t *testing.T) {
+	server := faulttest.NewServer()
+	defer server.Close()
+
+	var getBodyCalls int
+	transport := NewTransportWithDefaultRetries(http.DefaultTransport, fastTestRetryConfig())
+	script := "503,503,200"
+	req := newBodyRequest(t, server.URL, script, &getBodyCalls)
+
+	start := time.Now()
+	resp, err := transport.RoundTrip(req)
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected final status 200, got %d", resp.StatusCode)
+	}
+	if attempts := server.Attempts(script); attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+	if getBodyCalls != 3 {
+		t.Fatalf("expected GetBody called once per attempt (3), got %d", getBodyCalls)
+	}
+	if elapsed > 500*time.Millisecond {
+		t.Fatalf("retry loop took too long: %s", elapsed)
+	}
+}
+
+func TestRetryTransport_NonRetryableShortCircuits(t *testing.T) {
+	server := faulttest.NewServer()
+	defer server.Close()
+
+	var getBodyCalls int
+	transport := NewTransportWithDefaultRetries(http.DefaultTransport, fastTestRetryConfig())
+	script := "404"
+	req := newBodyRequest(t, server.URL, script, &getBodyCalls)
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", resp.StatusCode)
+	}
+	if attempts := server.Attempts(script); attempts != 1 {
+		t.Fatalf("expected exactly 1 attempt for a non-retryable error, got %d", attempts)
+	}
+}
+
+func TestRetryTransport_ContextCancellationAbortsPromptly(t *testing.T) {
+	server := faulttest.NewServer()
+	defer server.Close()
+
+	var getBodyCalls int
+	transport := NewTransportWithDefaultRetries(http.DefaultTransport, fastTestRetryConfig())
+	script := "503,503,503,503,503"
+	req := newBodyRequest(t, server.URL, script, &getBodyCalls)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	start := time.Now()
+	_, _ = transport.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	if elapsed > 200*time.Millisecond {
+		t.Fatalf("expected context cancellation to abort the retry loop promptly, took %s", elapsed)
+	}
+}
+
+func TestRetryTransport_ConnectionResetIsRetried(t *testing.T) {
+	server := faulttest.NewServer()
+	defer server.Close()
+
+	var getBodyCalls int
+	transport := NewTransportWithDefaultRetries(http.DefaultTransport, fastTestRetryConfig())
+	script := "reset,200"
+	req := newBodyRequest(t, server.URL, script, &getBodyCalls)
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected final status 200, got %d", resp.StatusCode)
+	}
+	if attempts := server.Attempts(script); attempts != 2 {
+		t.Fatalf("expected 2 attempts (reset then success), got %d", attempts)
+	}
+}
+
+func TestRetryTransport_SlowBodyRespectsDeadline(t *testing.T) {
+	server := faulttest.NewServer()
+	defer server.Close()
+
+	var getBodyCalls int
+	transport := NewTransportWithDefaultRetries(http.DefaultTransport, fastTestRetryConfig())
+	script := "slow:300ms"
+	req := newBodyRequest(t, server.URL, script, &getBodyCalls)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	start := time.Now()
+	_, _ = transport.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	if elapsed >= 300*time.Millisecond {
+		t.Fatalf("expected the request context deadline to abort the slow body instead of waiting it out, took %s", elapsed)
+	}
+}
+
+func TestRetryTransport_TruncatedBodyShortCircuits(t *testing.T) {
+	server := faulttest.NewServer()
+	defer server.Close()
+
+	var getBodyCalls int
+	transport := NewTransportWithDefaultRetries(http.DefaultTransport, fastTestRetryConfig())
+	script := "truncate"
+	req := newBodyRequest(t, server.URL, script, &getBodyCalls)
+
+	start := time.Now()
+	_, _ = transport.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	if elapsed > 500*time.Millisecond {
+		t.Fatalf("expected a truncated body to short-circuit the retry loop promptly, took %s", elapsed)
+	}
+	if attempts := server.Attempts(script); attempts != 1 {
+		t.Fatalf("expected exactly 1 attempt for a truncated body, got %d", attempts)
+	}
+}
+
+func TestRetryTransport_RetryAfterHeaderIsHonored(t *testing.T) {
+	server := faulttest.NewServer()
+	defer server.Close()
+
+	var getBodyCalls int
+	cfg := fastTestRetryConfig()
+	cfg.MaxBackoff = 2 * time.Second
+	cfg.TotalTimeout = 5 * time.Second
+	transport := NewTransportWithDefaultRetries(http.DefaultTransport, cfg)
+	script := "429:retry-after=1,200"
+	req := newBodyRequest(t, server.URL, script, &getBodyCalls)
+
+	start := time.Now()
+	resp, err := transport.RoundTrip(req)
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected final status 200, got %d", resp.StatusCode)
+	}
+	if attempts := server.Attempts(script); attempts != 2 {
+		t.Fatalf("expected 2 attempts, got %d", attempts)
+	}
+	if elapsed < time.Second {
+		t.Fatalf("expected the retry to wait out Retry-After: 1 instead of the 1ms local backoff, took %s", elapsed)
+	}
+}